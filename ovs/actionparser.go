@@ -0,0 +1,220 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// An actionParser splits a stream of flow actions into their raw,
+// comma-separated textual forms, taking care to treat commas inside
+// parentheses (such as those used by ct(...) or resubmit(...)) as part
+// of a single action rather than a delimiter between actions.
+type actionParser struct {
+	s *bufio.Scanner
+}
+
+// newActionParser creates an actionParser which reads from r.
+func newActionParser(r io.Reader) *actionParser {
+	return &actionParser{
+		s: bufio.NewScanner(r),
+	}
+}
+
+// Parse parses the actions from the actionParser's reader, returning both
+// the typed Actions and the raw, textual form of each action.
+func (p *actionParser) Parse() ([]Action, []string, error) {
+	var buf strings.Builder
+	for p.s.Scan() {
+		buf.WriteString(p.s.Text())
+		buf.WriteString("\n")
+	}
+	if err := p.s.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := splitActions(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actions := make([]Action, 0, len(raw))
+	for _, r := range raw {
+		a, err := parseAction(r)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		actions = append(actions, a)
+	}
+
+	return actions, raw, nil
+}
+
+// splitActions splits s into its comma-separated actions, treating commas
+// within parentheses as part of the enclosing action rather than as a
+// top-level delimiter. It returns an error if the parentheses in s are
+// not balanced.
+func splitActions(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var (
+		out   []string
+		depth int
+		start int
+	)
+
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("ovs: unbalanced parentheses in actions %q", s)
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("ovs: unbalanced parentheses in actions %q", s)
+	}
+
+	out = append(out, s[start:])
+	return out, nil
+}
+
+// splitArgs splits the comma-separated argument list s (the contents of a
+// "name(...)" action, with the enclosing parentheses already removed) in
+// the same, parenthesis-aware fashion as splitActions.
+func splitArgs(s string) ([]string, error) {
+	return splitActions(s)
+}
+
+// marshalActions marshals a slice of Actions back into their raw textual
+// forms.
+func marshalActions(actions []Action) ([]string, error) {
+	out := make([]string, 0, len(actions))
+	for _, a := range actions {
+		text, err := a.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, string(text))
+	}
+
+	return out, nil
+}
+
+// parseAction parses a single raw action string s into an Action.
+func parseAction(s string) (Action, error) {
+	switch {
+	case s == "drop":
+		return Drop(), nil
+	case s == "flood":
+		return Flood(), nil
+	case s == "in_port":
+		return InPort(), nil
+	case strings.EqualFold(s, "local"):
+		return Local(), nil
+	case strings.EqualFold(s, "normal"):
+		return Normal(), nil
+	case s == "strip_vlan":
+		return StripVLAN(), nil
+	case s == "pop_vlan":
+		return PopVLAN(), nil
+	case strings.HasPrefix(s, "push_vlan:"):
+		return parsePushVLAN(s)
+	case strings.HasPrefix(s, "push_mpls:"):
+		return parsePushMPLS(s)
+	case strings.HasPrefix(s, "pop_mpls:"):
+		return parsePopMPLS(s)
+	case strings.HasPrefix(s, "ct("):
+		return parseConnectionTracking(s)
+	case strings.HasPrefix(s, "bundle_load("):
+		return parseBundleLoad(s)
+	case strings.HasPrefix(s, "bundle("):
+		return parseBundle(s)
+	case strings.HasPrefix(s, "enqueue:"):
+		return parseEnqueueColon(s)
+	case strings.HasPrefix(s, "enqueue("):
+		return parseEnqueueParen(s)
+	case strings.HasPrefix(s, "group:"):
+		return parseGroup(s)
+	case s == "controller":
+		return Controller(0), nil
+	case strings.HasPrefix(s, "controller:"):
+		return parseController(s)
+	case strings.HasPrefix(s, "mod_dl_dst:"):
+		return parseModDataLink(s, "mod_dl_dst:", false)
+	case strings.HasPrefix(s, "mod_dl_src:"):
+		return parseModDataLink(s, "mod_dl_src:", true)
+	case strings.HasPrefix(s, "mod_nw_dst:"):
+		return parseModNetwork(s, "mod_nw_dst:", false)
+	case strings.HasPrefix(s, "mod_nw_src:"):
+		return parseModNetwork(s, "mod_nw_src:", true)
+	case strings.HasPrefix(s, "mod_tp_dst:"):
+		return parseModTransportPort(s, "mod_tp_dst:", false)
+	case strings.HasPrefix(s, "mod_tp_src:"):
+		return parseModTransportPort(s, "mod_tp_src:", true)
+	case strings.HasPrefix(s, "mod_vlan_vid:"):
+		return parseModVLANVID(s)
+	case strings.HasPrefix(s, "output:"):
+		return parseOutput(s)
+	case strings.HasPrefix(s, "resubmit:"):
+		return parseResubmitPort(s)
+	case strings.HasPrefix(s, "resubmit("):
+		return parseResubmit(s)
+	case strings.HasPrefix(s, "load:"):
+		return parseLoad(s)
+	case strings.HasPrefix(s, "move:"):
+		return parseMove(s)
+	case strings.HasPrefix(s, "set_field:"):
+		return parseSetField(s)
+	case strings.HasPrefix(s, "conjunction("):
+		return parseConjunction(s)
+	case strings.HasPrefix(s, "learn("):
+		return parseLearn(s)
+	default:
+		return nil, fmt.Errorf("ovs: unrecognized action: %q", s)
+	}
+}
+
+// parseHexUint parses a "0x"-prefixed hexadecimal string into a uint64,
+// returning an error for any value which is not valid hexadecimal.
+func parseHexUint(s string, bitSize int) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return 0, fmt.Errorf("ovs: value %q is not hexadecimal", s)
+	}
+
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("ovs: invalid hexadecimal value %q: %v", s, err)
+	}
+
+	return v, nil
+}
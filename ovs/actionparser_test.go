@@ -144,6 +144,87 @@ func Test_parseAction(t *testing.T) {
 			s: "strip_vlan",
 			a: StripVLAN(),
 		},
+		{
+			s: "pop_vlan",
+			a: PopVLAN(),
+		},
+		{
+			s:       "push_vlan:foo",
+			invalid: true,
+		},
+		{
+			s:       "push_vlan:0x1ffff",
+			invalid: true,
+		},
+		{
+			s: "push_vlan:0x8100",
+			a: PushVLAN(0x8100),
+		},
+		{
+			s:       "push_mpls:foo",
+			invalid: true,
+		},
+		{
+			s: "push_mpls:0x8848",
+			a: PushMPLS(0x8848),
+		},
+		{
+			s:       "pop_mpls:foo",
+			invalid: true,
+		},
+		{
+			s: "pop_mpls:0x8848",
+			a: PopMPLS(0x8848),
+		},
+		{
+			s: "bundle(eth_src,0,hrw,ofport,members:4,8)",
+			a: Bundle("eth_src", "hrw", 0, "ofport", []int{4, 8}),
+		},
+		{
+			s:       "bundle(eth_src,0,hrw,ofport,members:)",
+			invalid: true,
+		},
+		{
+			s:       "bundle(foo,0,hrw,ofport,members:4)",
+			invalid: true,
+		},
+		{
+			s:       "bundle(eth_src,0,foo,ofport,members:4)",
+			invalid: true,
+		},
+		{
+			s: "bundle_load(eth_src,0,hrw,ofport,reg0,members:4,8)",
+			a: BundleLoad("eth_src", "hrw", 0, "ofport", "reg0", []int{4, 8}),
+		},
+		{
+			s:       "enqueue:foo",
+			invalid: true,
+		},
+		{
+			s: "enqueue:1:2",
+			a: Enqueue("1", 2),
+		},
+		{
+			s:     "enqueue(1,2)",
+			a:     Enqueue("1", 2),
+			final: "enqueue:1:2",
+		},
+		{
+			s:       "group:foo",
+			invalid: true,
+		},
+		{
+			s: "group:5",
+			a: Group(5),
+		},
+		{
+			s: "controller",
+			a: Controller(0),
+		},
+		{
+			s: "controller:128",
+			a: Controller(128),
+		},
 		{
 			s:       "ct()",
 			invalid: true,
@@ -152,6 +233,18 @@ func Test_parseAction(t *testing.T) {
 			s: "ct(commit)",
 			a: ConnectionTracking("commit"),
 		},
+		{
+			s: "ct(commit,table=10,zone=5,nat(src=10.0.0.1-10.0.0.10:1024-65535,persistent))",
+			a: CT().Commit().Table(10).Zone(5).NAT(NATSrc("10.0.0.1-10.0.0.10:1024-65535", NATPersistent)),
+		},
+		{
+			s:       "ct(nat(src=10.0.0.1-bogus:1024-65535))",
+			invalid: true,
+		},
+		{
+			s:       "ct(nat(src=10.0.0.1-10.0.0.10:bogus-65535))",
+			invalid: true,
+		},
 		{
 			s:       "mod_dl_dst:foo",
 			invalid: true,
@@ -0,0 +1,1031 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// An Action is a flow action, used to instruct a switch to perform an
+// operation on a packet which matches a given flow.
+type Action interface {
+	GoString() string
+	MarshalText() (text []byte, err error)
+}
+
+// keywordAction is an Action with no arguments, such as "drop" or
+// "strip_vlan".
+type keywordAction string
+
+// keywordActionNames maps each keyword action's textual form to the name
+// of the exported constructor that produces it, for use by GoString.
+var keywordActionNames = map[string]string{
+	"drop":       "Drop",
+	"flood":      "Flood",
+	"in_port":    "InPort",
+	"local":      "Local",
+	"normal":     "Normal",
+	"strip_vlan": "StripVLAN",
+	"pop_vlan":   "PopVLAN",
+}
+
+func (a keywordAction) GoString() string {
+	name, ok := keywordActionNames[string(a)]
+	if !ok {
+		name = string(a)
+	}
+
+	return fmt.Sprintf("ovs.%s()", name)
+}
+
+func (a keywordAction) MarshalText() ([]byte, error) {
+	return []byte(a), nil
+}
+
+// Drop creates an Action that drops packets matching a flow.
+func Drop() Action { return keywordAction("drop") }
+
+// Flood creates an Action that outputs packets on all ports, except those
+// with flooding disabled.
+func Flood() Action { return keywordAction("flood") }
+
+// InPort creates an Action that outputs packets on the port on which they
+// entered the switch.
+func InPort() Action { return keywordAction("in_port") }
+
+// Local creates an Action that outputs packets on the local port.
+func Local() Action { return keywordAction("local") }
+
+// Normal creates an Action that subjects packets to the device's normal
+// L2/L3 processing.
+func Normal() Action { return keywordAction("normal") }
+
+// StripVLAN creates an Action that strips a VLAN tag from a packet.
+func StripVLAN() Action { return keywordAction("strip_vlan") }
+
+// PopVLAN creates an Action that pops a VLAN tag from a packet. It behaves
+// identically to StripVLAN, but marshals back to the "pop_vlan" spelling.
+func PopVLAN() Action { return keywordAction("pop_vlan") }
+
+// hexAction is an Action of the form "name:0x<value>", used by actions such
+// as PushVLAN, PushMPLS and PopMPLS which carry a 16-bit hexadecimal value.
+type hexAction struct {
+	name  string
+	value uint16
+}
+
+// hexActionNames maps each hexAction's name to the name of the exported
+// constructor that produces it, for use by GoString.
+var hexActionNames = map[string]string{
+	"push_vlan": "PushVLAN",
+	"push_mpls": "PushMPLS",
+	"pop_mpls":  "PopMPLS",
+}
+
+func (a *hexAction) GoString() string {
+	name, ok := hexActionNames[a.name]
+	if !ok {
+		name = a.name
+	}
+
+	return fmt.Sprintf("ovs.%s(%#04x)", name, a.value)
+}
+
+func (a *hexAction) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s:0x%04x", a.name, a.value)), nil
+}
+
+// PushVLAN creates an Action that pushes a new VLAN header onto a packet,
+// using tpid as the tag protocol identifier (typically 0x8100).
+func PushVLAN(tpid uint16) Action {
+	return &hexAction{name: "push_vlan", value: tpid}
+}
+
+func parsePushVLAN(s string) (Action, error) {
+	v, err := parseHexUint(strings.TrimPrefix(s, "push_vlan:"), 16)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid push_vlan TPID: %v", err)
+	}
+
+	return PushVLAN(uint16(v)), nil
+}
+
+// PushMPLS creates an Action that pushes a new MPLS header onto a packet,
+// using ethertype to indicate the MPLS ethertype (typically 0x8847 or
+// 0x8848).
+func PushMPLS(ethertype uint16) Action {
+	return &hexAction{name: "push_mpls", value: ethertype}
+}
+
+func parsePushMPLS(s string) (Action, error) {
+	v, err := parseHexUint(strings.TrimPrefix(s, "push_mpls:"), 16)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid push_mpls ethertype: %v", err)
+	}
+
+	return PushMPLS(uint16(v)), nil
+}
+
+// PopMPLS creates an Action that pops an MPLS header from a packet, using
+// ethertype to indicate the resulting ethertype of the packet.
+func PopMPLS(ethertype uint16) Action {
+	return &hexAction{name: "pop_mpls", value: ethertype}
+}
+
+func parsePopMPLS(s string) (Action, error) {
+	v, err := parseHexUint(strings.TrimPrefix(s, "pop_mpls:"), 16)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid pop_mpls ethertype: %v", err)
+	}
+
+	return PopMPLS(uint16(v)), nil
+}
+
+// A connectionTracking is an Action which invokes the connection tracking
+// subsystem, optionally carrying one or more comma-separated arguments.
+type connectionTracking struct {
+	Args string
+}
+
+// ConnectionTracking creates an Action which invokes connection tracking
+// using the raw, comma-separated args (for example, "commit").
+func ConnectionTracking(args string) Action {
+	return &connectionTracking{Args: args}
+}
+
+func (a *connectionTracking) GoString() string {
+	return fmt.Sprintf("ovs.ConnectionTracking(%q)", a.Args)
+}
+
+func (a *connectionTracking) MarshalText() ([]byte, error) {
+	if a.Args == "" {
+		return nil, fmt.Errorf("ovs: ct action requires at least one argument")
+	}
+
+	return []byte(fmt.Sprintf("ct(%s)", a.Args)), nil
+}
+
+func parseConnectionTracking(s string) (Action, error) {
+	args := strings.TrimSuffix(strings.TrimPrefix(s, "ct("), ")")
+	if !strings.HasSuffix(s, ")") || args == "" {
+		return nil, fmt.Errorf("ovs: invalid ct action: %q", s)
+	}
+
+	return parseCTAction(args)
+}
+
+// A modDataLink is an Action which rewrites a packet's Ethernet source or
+// destination address.
+type modDataLink struct {
+	Src  bool
+	Addr net.HardwareAddr
+}
+
+// ModDataLinkDestination creates an Action that rewrites a packet's
+// destination Ethernet address.
+func ModDataLinkDestination(addr net.HardwareAddr) Action {
+	return &modDataLink{Addr: addr}
+}
+
+// ModDataLinkSource creates an Action that rewrites a packet's source
+// Ethernet address.
+func ModDataLinkSource(addr net.HardwareAddr) Action {
+	return &modDataLink{Src: true, Addr: addr}
+}
+
+func (a *modDataLink) key() string {
+	if a.Src {
+		return "mod_dl_src"
+	}
+
+	return "mod_dl_dst"
+}
+
+func (a *modDataLink) GoString() string {
+	return fmt.Sprintf("ovs.ModDataLink(%t, %q)", a.Src, a.Addr.String())
+}
+
+func (a *modDataLink) MarshalText() ([]byte, error) {
+	if len(a.Addr) != 6 {
+		return nil, fmt.Errorf("ovs: invalid Ethernet address: %v", a.Addr)
+	}
+
+	return []byte(fmt.Sprintf("%s:%s", a.key(), a.Addr.String())), nil
+}
+
+func parseModDataLink(s, prefix string, src bool) (Action, error) {
+	addr, err := net.ParseMAC(strings.TrimPrefix(s, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid Ethernet address in %q: %v", s, err)
+	}
+
+	if src {
+		return ModDataLinkSource(addr), nil
+	}
+
+	return ModDataLinkDestination(addr), nil
+}
+
+// A modNetwork is an Action which rewrites a packet's IPv4 source or
+// destination address.
+type modNetwork struct {
+	Src bool
+	IP  net.IP
+}
+
+// ModNetworkDestination creates an Action that rewrites a packet's
+// destination IPv4 address.
+func ModNetworkDestination(ip net.IP) Action {
+	return &modNetwork{IP: ip}
+}
+
+// ModNetworkSource creates an Action that rewrites a packet's source IPv4
+// address.
+func ModNetworkSource(ip net.IP) Action {
+	return &modNetwork{Src: true, IP: ip}
+}
+
+func (a *modNetwork) key() string {
+	if a.Src {
+		return "mod_nw_src"
+	}
+
+	return "mod_nw_dst"
+}
+
+func (a *modNetwork) GoString() string {
+	return fmt.Sprintf("ovs.ModNetwork(%t, %q)", a.Src, a.IP.String())
+}
+
+func (a *modNetwork) MarshalText() ([]byte, error) {
+	ip4 := a.IP.To4()
+	if ip4 == nil {
+		return nil, fmt.Errorf("ovs: invalid IPv4 address: %v", a.IP)
+	}
+
+	return []byte(fmt.Sprintf("%s:%s", a.key(), ip4.String())), nil
+}
+
+func parseModNetwork(s, prefix string, src bool) (Action, error) {
+	ip := net.ParseIP(strings.TrimPrefix(s, prefix))
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("ovs: invalid IPv4 address in %q", s)
+	}
+
+	if src {
+		return ModNetworkSource(ip), nil
+	}
+
+	return ModNetworkDestination(ip), nil
+}
+
+// A modTransportPort is an Action which rewrites a packet's TCP/UDP source
+// or destination port.
+type modTransportPort struct {
+	Src  bool
+	Port int
+}
+
+// ModTransportDestinationPort creates an Action that rewrites a packet's
+// destination TCP/UDP port.
+func ModTransportDestinationPort(port int) Action {
+	return &modTransportPort{Port: port}
+}
+
+// ModTransportSourcePort creates an Action that rewrites a packet's source
+// TCP/UDP port.
+func ModTransportSourcePort(port int) Action {
+	return &modTransportPort{Src: true, Port: port}
+}
+
+func (a *modTransportPort) key() string {
+	if a.Src {
+		return "mod_tp_src"
+	}
+
+	return "mod_tp_dst"
+}
+
+func (a *modTransportPort) GoString() string {
+	return fmt.Sprintf("ovs.ModTransportPort(%t, %d)", a.Src, a.Port)
+}
+
+func (a *modTransportPort) MarshalText() ([]byte, error) {
+	if a.Port < 0 || a.Port > 65535 {
+		return nil, fmt.Errorf("ovs: invalid transport port: %d", a.Port)
+	}
+
+	return []byte(fmt.Sprintf("%s:%d", a.key(), a.Port)), nil
+}
+
+func parseModTransportPort(s, prefix string, src bool) (Action, error) {
+	port, err := strconv.Atoi(strings.TrimPrefix(s, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid transport port in %q: %v", s, err)
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("ovs: invalid transport port in %q", s)
+	}
+
+	if src {
+		return ModTransportSourcePort(port), nil
+	}
+
+	return ModTransportDestinationPort(port), nil
+}
+
+// A modVLANVID is an Action which rewrites a packet's VLAN ID.
+type modVLANVID struct {
+	VID int
+}
+
+// ModVLANVID creates an Action that rewrites a packet's VLAN ID.
+func ModVLANVID(vid int) Action {
+	return &modVLANVID{VID: vid}
+}
+
+func (a *modVLANVID) GoString() string {
+	return fmt.Sprintf("ovs.ModVLANVID(%d)", a.VID)
+}
+
+func (a *modVLANVID) MarshalText() ([]byte, error) {
+	if a.VID < 0 || a.VID > 4095 {
+		return nil, fmt.Errorf("ovs: invalid VLAN ID: %d", a.VID)
+	}
+
+	return []byte(fmt.Sprintf("mod_vlan_vid:%d", a.VID)), nil
+}
+
+func parseModVLANVID(s string) (Action, error) {
+	vid, err := strconv.Atoi(strings.TrimPrefix(s, "mod_vlan_vid:"))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid VLAN ID in %q: %v", s, err)
+	}
+
+	return ModVLANVID(vid), nil
+}
+
+// An output is an Action which outputs a packet on a port, either
+// specified numerically or by an NXM/OXM field reference.
+type output struct {
+	Port  int
+	Field string
+}
+
+// Output creates an Action that outputs a packet on the specified port.
+func Output(port int) Action {
+	return &output{Port: port}
+}
+
+// OutputField creates an Action that outputs a packet on the port named by
+// the given NXM/OXM field, such as "NXM_OF_IN_PORT[]".
+func OutputField(field string) Action {
+	return &output{Field: field}
+}
+
+func (a *output) GoString() string {
+	if a.Field != "" {
+		return fmt.Sprintf("ovs.OutputField(%q)", a.Field)
+	}
+
+	return fmt.Sprintf("ovs.Output(%d)", a.Port)
+}
+
+func (a *output) MarshalText() ([]byte, error) {
+	if a.Field != "" {
+		return []byte(fmt.Sprintf("output:%s", a.Field)), nil
+	}
+
+	if a.Port < 0 {
+		return nil, fmt.Errorf("ovs: invalid output port: %d", a.Port)
+	}
+
+	return []byte(fmt.Sprintf("output:%d", a.Port)), nil
+}
+
+func parseOutput(s string) (Action, error) {
+	v := strings.TrimPrefix(s, "output:")
+	if strings.ContainsAny(v, "[]") {
+		return OutputField(v), nil
+	}
+
+	port, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid output port in %q: %v", s, err)
+	}
+
+	return Output(port), nil
+}
+
+// A resubmitPort is an Action which resubmits a packet to the given port
+// within the same table, using the "resubmit:<port>" spelling.
+type resubmitPort struct {
+	Port int
+}
+
+// ResubmitPort creates an Action that resubmits a packet to the given port
+// in the current table.
+func ResubmitPort(port int) Action {
+	return &resubmitPort{Port: port}
+}
+
+func (a *resubmitPort) GoString() string {
+	return fmt.Sprintf("ovs.ResubmitPort(%d)", a.Port)
+}
+
+func (a *resubmitPort) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("resubmit:%d", a.Port)), nil
+}
+
+func parseResubmitPort(s string) (Action, error) {
+	port, err := strconv.Atoi(strings.TrimPrefix(s, "resubmit:"))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid resubmit port in %q: %v", s, err)
+	}
+
+	return ResubmitPort(port), nil
+}
+
+// A resubmit is an Action which resubmits a packet to the given port
+// and/or table, using the "resubmit(<port>,<table>)" spelling. A zero
+// value for either field is rendered as an empty field, matching OVS's own
+// convention for "unspecified".
+type resubmit struct {
+	Port  int
+	Table int
+}
+
+// Resubmit creates an Action that resubmits a packet to the given port and
+// table. A port or table of zero is treated as unspecified.
+func Resubmit(port, table int) Action {
+	return &resubmit{Port: port, Table: table}
+}
+
+func (a *resubmit) GoString() string {
+	return fmt.Sprintf("ovs.Resubmit(%d, %d)", a.Port, a.Table)
+}
+
+func (a *resubmit) MarshalText() ([]byte, error) {
+	var port, table string
+	if a.Port != 0 {
+		port = strconv.Itoa(a.Port)
+	}
+	if a.Table != 0 {
+		table = strconv.Itoa(a.Table)
+	}
+
+	return []byte(fmt.Sprintf("resubmit(%s,%s)", port, table)), nil
+}
+
+func parseResubmit(s string) (Action, error) {
+	args := strings.TrimSuffix(strings.TrimPrefix(s, "resubmit("), ")")
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("ovs: invalid resubmit action: %q", s)
+	}
+
+	parts := strings.Split(args, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("ovs: resubmit requires a port and table: %q", s)
+	}
+
+	port, err := parseOptionalInt(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid resubmit port in %q: %v", s, err)
+	}
+
+	table, err := parseOptionalInt(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid resubmit table in %q: %v", s, err)
+	}
+
+	return Resubmit(port, table), nil
+}
+
+// parseOptionalInt parses s as an integer, treating an empty string as
+// zero.
+func parseOptionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
+// A load is an Action which loads a literal value into an NXM/OXM field.
+type load struct {
+	Value string
+	Dst   string
+}
+
+// Load creates an Action that loads value into the NXM/OXM field dst.
+func Load(value, dst string) Action {
+	return &load{Value: value, Dst: dst}
+}
+
+func (a *load) GoString() string {
+	return fmt.Sprintf("ovs.Load(%q, %q)", a.Value, a.Dst)
+}
+
+func (a *load) MarshalText() ([]byte, error) {
+	if a.Value == "" || a.Dst == "" {
+		return nil, fmt.Errorf("ovs: load requires a value and destination field")
+	}
+
+	return []byte(fmt.Sprintf("load:%s->%s", a.Value, a.Dst)), nil
+}
+
+func parseLoad(s string) (Action, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "load:"), "->", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("ovs: invalid load action: %q", s)
+	}
+
+	return Load(parts[0], parts[1]), nil
+}
+
+// A move is an Action which copies a value from one NXM/OXM field to
+// another.
+type move struct {
+	Src string
+	Dst string
+}
+
+// Move creates an Action that copies src into dst.
+func Move(src, dst string) Action {
+	return &move{Src: src, Dst: dst}
+}
+
+func (a *move) GoString() string {
+	return fmt.Sprintf("ovs.Move(%q, %q)", a.Src, a.Dst)
+}
+
+func (a *move) MarshalText() ([]byte, error) {
+	if a.Src == "" || a.Dst == "" {
+		return nil, fmt.Errorf("ovs: move requires a source and destination field")
+	}
+
+	return []byte(fmt.Sprintf("%s->%s", a.Src, a.Dst)), nil
+}
+
+func parseMove(s string) (Action, error) {
+	parts := strings.SplitN(s, "->", 2)
+	if len(parts) != 2 || strings.TrimPrefix(parts[0], "move:") == "" || parts[1] == "" {
+		return nil, fmt.Errorf("ovs: invalid move action: %q", s)
+	}
+
+	return Move(parts[0], parts[1]), nil
+}
+
+// A setField is an Action which sets a packet field to a literal value.
+type setField struct {
+	Value string
+	Field string
+}
+
+// SetField creates an Action that sets field to value.
+func SetField(value, field string) Action {
+	return &setField{Value: value, Field: field}
+}
+
+func (a *setField) GoString() string {
+	return fmt.Sprintf("ovs.SetField(%q, %q)", a.Value, a.Field)
+}
+
+func (a *setField) MarshalText() ([]byte, error) {
+	if a.Value == "" || a.Field == "" {
+		return nil, fmt.Errorf("ovs: set_field requires a value and field")
+	}
+
+	return []byte(fmt.Sprintf("set_field:%s->%s", a.Value, a.Field)), nil
+}
+
+func parseSetField(s string) (Action, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "set_field:"), "->", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("ovs: invalid set_field action: %q", s)
+	}
+
+	return SetField(parts[0], parts[1]), nil
+}
+
+// A conjunction is an Action used to implement multi-dimensional flow
+// matches, splitting the match across N discrete sub-flows.
+type conjunction struct {
+	ID    uint32
+	N     uint8
+	Total uint8
+}
+
+// Conjunction creates an Action identifying one dimension (n of total) of
+// a multi-dimensional conjunctive match, keyed by id.
+func Conjunction(id uint32, n, total uint8) Action {
+	return &conjunction{ID: id, N: n, Total: total}
+}
+
+func (a *conjunction) GoString() string {
+	return fmt.Sprintf("ovs.Conjunction(%d, %d, %d)", a.ID, a.N, a.Total)
+}
+
+func (a *conjunction) MarshalText() ([]byte, error) {
+	if a.N == 0 || a.Total == 0 || a.N > a.Total {
+		return nil, fmt.Errorf("ovs: invalid conjunction dimension %d of %d", a.N, a.Total)
+	}
+
+	return []byte(fmt.Sprintf("conjunction(%d,%d/%d)", a.ID, a.N, a.Total)), nil
+}
+
+func parseConjunction(s string) (Action, error) {
+	args := strings.TrimSuffix(strings.TrimPrefix(s, "conjunction("), ")")
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("ovs: invalid conjunction action: %q", s)
+	}
+
+	parts := strings.Split(args, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("ovs: invalid conjunction action: %q", s)
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid conjunction id in %q: %v", s, err)
+	}
+
+	nTotal := strings.SplitN(parts[1], "/", 2)
+	if len(nTotal) != 2 {
+		return nil, fmt.Errorf("ovs: invalid conjunction dimension in %q", s)
+	}
+
+	n, err := strconv.ParseUint(nTotal[0], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid conjunction dimension in %q: %v", s, err)
+	}
+
+	total, err := strconv.ParseUint(nTotal[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid conjunction dimension in %q: %v", s, err)
+	}
+
+	if n == 0 || total == 0 || n > total {
+		return nil, fmt.Errorf("ovs: invalid conjunction dimension %d of %d", n, total)
+	}
+
+	return Conjunction(uint32(id), uint8(n), uint8(total)), nil
+}
+
+// Valid values for the fields, algorithm and slaveType arguments of Bundle
+// and BundleLoad.
+const (
+	BundleFieldsEthSrc      = "eth_src"
+	BundleFieldsSymmetricL4 = "symmetric_l4"
+
+	BundleAlgorithmActiveBackup = "active_backup"
+	BundleAlgorithmHRW          = "hrw"
+
+	BundleSlaveTypeOFPort = "ofport"
+)
+
+// A bundle is an Action which selects one of a set of member ports based
+// on a hash of packet fields, and outputs the packet on that port.
+type bundle struct {
+	Fields    string
+	Basis     uint16
+	Algorithm string
+	SlaveType string
+	Members   []int
+
+	// Dst is only set for bundle_load; it names the NXM/OXM field that the
+	// selected member's port number is written to, instead of the packet
+	// being output directly.
+	Dst string
+}
+
+// Bundle creates an Action that outputs a packet on one of members, chosen
+// by hashing fields using algorithm and basis.
+func Bundle(fields, algorithm string, basis uint16, slaveType string, members []int) Action {
+	return &bundle{
+		Fields:    fields,
+		Basis:     basis,
+		Algorithm: algorithm,
+		SlaveType: slaveType,
+		Members:   members,
+	}
+}
+
+// BundleLoad creates an Action that hashes fields using algorithm and
+// basis to choose one of members, and writes its port number into the
+// NXM/OXM field dst.
+func BundleLoad(fields, algorithm string, basis uint16, slaveType, dst string, members []int) Action {
+	return &bundle{
+		Fields:    fields,
+		Basis:     basis,
+		Algorithm: algorithm,
+		SlaveType: slaveType,
+		Members:   members,
+		Dst:       dst,
+	}
+}
+
+func (a *bundle) name() string {
+	if a.Dst != "" {
+		return "bundle_load"
+	}
+
+	return "bundle"
+}
+
+func (a *bundle) GoString() string {
+	members := make([]string, len(a.Members))
+	for i, m := range a.Members {
+		members[i] = strconv.Itoa(m)
+	}
+	membersLit := fmt.Sprintf("[]int{%s}", strings.Join(members, ", "))
+
+	if a.Dst != "" {
+		return fmt.Sprintf("ovs.BundleLoad(%q, %q, %d, %q, %q, %s)", a.Fields, a.Algorithm, a.Basis, a.SlaveType, a.Dst, membersLit)
+	}
+
+	return fmt.Sprintf("ovs.Bundle(%q, %q, %d, %q, %s)", a.Fields, a.Algorithm, a.Basis, a.SlaveType, membersLit)
+}
+
+func (a *bundle) validate() error {
+	switch a.Fields {
+	case BundleFieldsEthSrc, BundleFieldsSymmetricL4:
+	default:
+		return fmt.Errorf("ovs: invalid bundle fields: %q", a.Fields)
+	}
+
+	switch a.Algorithm {
+	case BundleAlgorithmActiveBackup, BundleAlgorithmHRW:
+	default:
+		return fmt.Errorf("ovs: invalid bundle algorithm: %q", a.Algorithm)
+	}
+
+	switch a.SlaveType {
+	case BundleSlaveTypeOFPort:
+	default:
+		return fmt.Errorf("ovs: invalid bundle slave_type: %q", a.SlaveType)
+	}
+
+	if len(a.Members) == 0 {
+		return fmt.Errorf("ovs: bundle requires at least one member")
+	}
+
+	if a.name() == "bundle_load" && !isValidField(a.Dst) {
+		return fmt.Errorf("ovs: invalid bundle_load destination field: %q", a.Dst)
+	}
+
+	return nil
+}
+
+func (a *bundle) MarshalText() ([]byte, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	members := make([]string, len(a.Members))
+	for i, m := range a.Members {
+		members[i] = strconv.Itoa(m)
+	}
+
+	dst := ""
+	if a.Dst != "" {
+		dst = a.Dst + ","
+	}
+
+	return []byte(fmt.Sprintf(
+		"%s(%s,%d,%s,%s,%smembers:%s)",
+		a.name(), a.Fields, a.Basis, a.Algorithm, a.SlaveType, dst, strings.Join(members, ","),
+	)), nil
+}
+
+// isValidField reports whether s looks like a usable NXM/OXM field or
+// register reference, such as "reg0" or "NXM_OF_IN_PORT[]".
+func isValidField(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '[', r == ']':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseBundleArgs(s, prefix string) (*bundle, error) {
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("ovs: invalid %s action: %q", prefix, s)
+	}
+
+	args, err := splitArgs(strings.TrimSuffix(strings.TrimPrefix(s, prefix+"("), ")"))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid %s action: %v", prefix, err)
+	}
+
+	minArgs := 5
+	if prefix == "bundle_load" {
+		minArgs = 6
+	}
+	if len(args) < minArgs {
+		return nil, fmt.Errorf("ovs: %s requires fields, basis, algorithm, slave_type and members: %q", prefix, s)
+	}
+
+	basis, err := strconv.ParseUint(args[1], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid %s basis in %q: %v", prefix, s, err)
+	}
+
+	b := &bundle{
+		Fields:    args[0],
+		Basis:     uint16(basis),
+		Algorithm: args[2],
+		SlaveType: args[3],
+	}
+
+	memberIdx := 4
+	if prefix == "bundle_load" {
+		b.Dst = args[4]
+		memberIdx = 5
+	}
+
+	if !strings.HasPrefix(args[memberIdx], "members:") {
+		return nil, fmt.Errorf("ovs: %s expected members clause in %q", prefix, s)
+	}
+
+	first := strings.TrimPrefix(args[memberIdx], "members:")
+	memberTokens := append([]string{first}, args[memberIdx+1:]...)
+	for _, m := range memberTokens {
+		port, err := strconv.Atoi(m)
+		if err != nil {
+			return nil, fmt.Errorf("ovs: invalid %s member in %q: %v", prefix, s, err)
+		}
+
+		b.Members = append(b.Members, port)
+	}
+
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func parseBundle(s string) (Action, error) {
+	b, err := parseBundleArgs(s, "bundle")
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func parseBundleLoad(s string) (Action, error) {
+	b, err := parseBundleArgs(s, "bundle_load")
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// An enqueue is an Action which outputs a packet on a port's queue, used
+// for QoS.
+type enqueue struct {
+	Port  string
+	Queue uint32
+}
+
+// Enqueue creates an Action that outputs a packet on queue of port.
+func Enqueue(port string, queue uint32) Action {
+	return &enqueue{Port: port, Queue: queue}
+}
+
+func (a *enqueue) GoString() string {
+	return fmt.Sprintf("ovs.Enqueue(%q, %d)", a.Port, a.Queue)
+}
+
+func (a *enqueue) MarshalText() ([]byte, error) {
+	if a.Port == "" {
+		return nil, fmt.Errorf("ovs: enqueue requires a port")
+	}
+
+	return []byte(fmt.Sprintf("enqueue:%s:%d", a.Port, a.Queue)), nil
+}
+
+func parseEnqueueColon(s string) (Action, error) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "enqueue:"), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("ovs: invalid enqueue action: %q", s)
+	}
+
+	queue, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid enqueue queue in %q: %v", s, err)
+	}
+
+	return Enqueue(parts[0], uint32(queue)), nil
+}
+
+func parseEnqueueParen(s string) (Action, error) {
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("ovs: invalid enqueue action: %q", s)
+	}
+
+	args, err := splitArgs(strings.TrimSuffix(strings.TrimPrefix(s, "enqueue("), ")"))
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid enqueue action: %v", err)
+	}
+
+	if len(args) != 2 || args[0] == "" {
+		return nil, fmt.Errorf("ovs: enqueue requires a port and queue: %q", s)
+	}
+
+	queue, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid enqueue queue in %q: %v", s, err)
+	}
+
+	return Enqueue(args[0], uint32(queue)), nil
+}
+
+// A group is an Action which outputs a packet to an OpenFlow group table
+// entry.
+type group struct {
+	ID uint32
+}
+
+// Group creates an Action that outputs a packet to the group table entry
+// identified by id.
+func Group(id uint32) Action {
+	return &group{ID: id}
+}
+
+func (a *group) GoString() string {
+	return fmt.Sprintf("ovs.Group(%d)", a.ID)
+}
+
+func (a *group) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("group:%d", a.ID)), nil
+}
+
+func parseGroup(s string) (Action, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(s, "group:"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid group id in %q: %v", s, err)
+	}
+
+	return Group(uint32(id)), nil
+}
+
+// A controller is an Action which sends a packet to the OpenFlow
+// controller. A MaxLen of zero sends the entire packet, marshaling as the
+// bare "controller" keyword.
+type controller struct {
+	MaxLen uint16
+}
+
+// Controller creates an Action that sends a packet to the controller,
+// truncated to maxLen bytes. A maxLen of zero sends the entire packet.
+func Controller(maxLen uint16) Action {
+	return &controller{MaxLen: maxLen}
+}
+
+func (a *controller) GoString() string {
+	return fmt.Sprintf("ovs.Controller(%d)", a.MaxLen)
+}
+
+func (a *controller) MarshalText() ([]byte, error) {
+	if a.MaxLen == 0 {
+		return []byte("controller"), nil
+	}
+
+	return []byte(fmt.Sprintf("controller:%d", a.MaxLen)), nil
+}
+
+func parseController(s string) (Action, error) {
+	maxLen, err := strconv.ParseUint(strings.TrimPrefix(s, "controller:"), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid controller max_len in %q: %v", s, err)
+	}
+
+	return Controller(uint16(maxLen)), nil
+}
@@ -0,0 +1,395 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// A NATFlag modifies the behavior of a NATAction, such as NATSrc or
+// NATDst.
+type NATFlag string
+
+// Possible NATFlag values, used to modify a NATAction.
+const (
+	NATPersistent NATFlag = "persistent"
+	NATHash       NATFlag = "hash"
+	NATRandom     NATFlag = "random"
+)
+
+// natFlagNames maps each NATFlag to the name of the exported constant that
+// holds it, for use by NATAction.GoString.
+var natFlagNames = map[NATFlag]string{
+	NATPersistent: "NATPersistent",
+	NATHash:       "NATHash",
+	NATRandom:     "NATRandom",
+}
+
+// A NATAction is a nat(...) sub-action of a ConnectionTracking action,
+// used to rewrite a packet's source or destination address and/or port
+// as part of connection tracking.
+type NATAction struct {
+	dir   string
+	rng   string
+	flags []NATFlag
+}
+
+// NAT creates a bare NATAction ("nat"), letting OVS choose an address from
+// the configured NAT range without specifying one explicitly.
+func NAT() *NATAction {
+	return &NATAction{}
+}
+
+// NATSrc creates a NATAction that rewrites a packet's source address
+// and/or port using the given IP/port range, such as
+// "10.0.0.1-10.0.0.10:1024-65535".
+func NATSrc(rng string, flags ...NATFlag) *NATAction {
+	return &NATAction{dir: "src", rng: rng, flags: flags}
+}
+
+// NATDst creates a NATAction that rewrites a packet's destination address
+// and/or port using the given IP/port range.
+func NATDst(rng string, flags ...NATFlag) *NATAction {
+	return &NATAction{dir: "dst", rng: rng, flags: flags}
+}
+
+// GoString returns a Go syntax representation of n, reconstructing the
+// constructor call used to create it.
+func (n *NATAction) GoString() string {
+	if n.dir == "" && n.rng == "" && len(n.flags) == 0 {
+		return "ovs.NAT()"
+	}
+
+	flags := make([]string, len(n.flags))
+	for i, f := range n.flags {
+		flags[i] = "ovs." + natFlagNames[f]
+	}
+
+	args := append([]string{fmt.Sprintf("%q", n.rng)}, flags...)
+
+	name := "NATSrc"
+	if n.dir == "dst" {
+		name = "NATDst"
+	}
+
+	return fmt.Sprintf("ovs.%s(%s)", name, strings.Join(args, ", "))
+}
+
+func (n *NATAction) marshal() (string, error) {
+	if n.dir == "" && n.rng == "" && len(n.flags) == 0 {
+		return "nat", nil
+	}
+
+	var parts []string
+
+	if n.rng != "" {
+		if err := validateNATRange(n.rng); err != nil {
+			return "", err
+		}
+
+		parts = append(parts, fmt.Sprintf("%s=%s", n.dir, n.rng))
+	}
+
+	for _, f := range n.flags {
+		switch f {
+		case NATPersistent, NATHash, NATRandom:
+		default:
+			return "", fmt.Errorf("ovs: invalid nat flag: %q", f)
+		}
+
+		parts = append(parts, string(f))
+	}
+
+	return fmt.Sprintf("nat(%s)", strings.Join(parts, ",")), nil
+}
+
+// validateNATRange validates a NAT IP/port range of the form
+// "IP[-IP][:PORT[-PORT]]".
+func validateNATRange(s string) error {
+	ipPort := strings.SplitN(s, ":", 2)
+
+	for _, ip := range strings.SplitN(ipPort[0], "-", 2) {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("ovs: invalid NAT IP address: %q", ip)
+		}
+	}
+
+	if len(ipPort) != 2 {
+		return nil
+	}
+
+	for _, p := range strings.SplitN(ipPort[1], "-", 2) {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || port == 0 {
+			return fmt.Errorf("ovs: invalid NAT port: %q", p)
+		}
+	}
+
+	return nil
+}
+
+func parseNAT(args string) (*NATAction, error) {
+	n := &NATAction{}
+
+	clauses, err := splitArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid nat action: %v", err)
+	}
+
+	for _, c := range clauses {
+		if c == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(c, "src="):
+			n.dir = "src"
+			n.rng = strings.TrimPrefix(c, "src=")
+		case strings.HasPrefix(c, "dst="):
+			n.dir = "dst"
+			n.rng = strings.TrimPrefix(c, "dst=")
+		case NATFlag(c) == NATPersistent, NATFlag(c) == NATHash, NATFlag(c) == NATRandom:
+			n.flags = append(n.flags, NATFlag(c))
+		default:
+			return nil, fmt.Errorf("ovs: invalid nat clause: %q", c)
+		}
+	}
+
+	if n.rng != "" {
+		if err := validateNATRange(n.rng); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// A CTAction is a typed, programmatic builder for a ConnectionTracking
+// action, used in place of hand-formatting the raw ct(...) argument
+// string. Methods return the receiver so that calls can be chained, for
+// example:
+//
+//	CT().Commit().Table(10).Zone(5).NAT(NATSrc("10.0.0.1-10.0.0.10:1024-65535", NATPersistent))
+type CTAction struct {
+	commit bool
+	force  bool
+	table  *uint8
+	zone   *uint16
+	nat    *NATAction
+	alg    string
+	exec   []Action
+}
+
+// CT creates a new, empty CTAction builder.
+func CT() *CTAction {
+	return &CTAction{}
+}
+
+// Commit marks the connection as committed to the connection tracking
+// table.
+func (c *CTAction) Commit() *CTAction {
+	c.commit = true
+	return c
+}
+
+// Force forces a committed connection to be re-evaluated against the
+// current flow's match criteria.
+func (c *CTAction) Force() *CTAction {
+	c.force = true
+	return c
+}
+
+// Table sets the flow table that the packet should be resubmitted to
+// after connection tracking is applied.
+func (c *CTAction) Table(table uint8) *CTAction {
+	c.table = &table
+	return c
+}
+
+// Zone sets the connection tracking zone used to track the connection.
+func (c *CTAction) Zone(zone uint16) *CTAction {
+	c.zone = &zone
+	return c
+}
+
+// NAT attaches a NATAction describing how addresses and ports should be
+// rewritten as part of connection tracking.
+func (c *CTAction) NAT(nat *NATAction) *CTAction {
+	c.nat = nat
+	return c
+}
+
+// ALG enables tracking for an application-layer gateway, such as "ftp".
+func (c *CTAction) ALG(alg string) *CTAction {
+	c.alg = alg
+	return c
+}
+
+// Exec attaches actions to be executed against the tracked connection's
+// fields, such as set_field to initialize ct_mark or ct_label.
+func (c *CTAction) Exec(actions ...Action) *CTAction {
+	c.exec = actions
+	return c
+}
+
+// GoString returns a Go syntax representation of c, reconstructing the
+// chained builder calls used to create it.
+func (c *CTAction) GoString() string {
+	var b strings.Builder
+	b.WriteString("ovs.CT()")
+
+	if c.commit {
+		b.WriteString(".Commit()")
+	}
+	if c.force {
+		b.WriteString(".Force()")
+	}
+	if c.table != nil {
+		fmt.Fprintf(&b, ".Table(%d)", *c.table)
+	}
+	if c.zone != nil {
+		fmt.Fprintf(&b, ".Zone(%d)", *c.zone)
+	}
+	if c.nat != nil {
+		fmt.Fprintf(&b, ".NAT(%s)", c.nat.GoString())
+	}
+	if c.alg != "" {
+		fmt.Fprintf(&b, ".ALG(%q)", c.alg)
+	}
+	if len(c.exec) > 0 {
+		execs := make([]string, len(c.exec))
+		for i, a := range c.exec {
+			execs[i] = a.GoString()
+		}
+		fmt.Fprintf(&b, ".Exec(%s)", strings.Join(execs, ", "))
+	}
+
+	return b.String()
+}
+
+func (c *CTAction) MarshalText() ([]byte, error) {
+	var clauses []string
+
+	if c.commit {
+		clauses = append(clauses, "commit")
+	}
+	if c.force {
+		clauses = append(clauses, "force")
+	}
+	if c.table != nil {
+		clauses = append(clauses, fmt.Sprintf("table=%d", *c.table))
+	}
+	if c.zone != nil {
+		clauses = append(clauses, fmt.Sprintf("zone=%d", *c.zone))
+	}
+	if c.nat != nil {
+		nat, err := c.nat.marshal()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, nat)
+	}
+	if c.alg != "" {
+		clauses = append(clauses, fmt.Sprintf("alg=%s", c.alg))
+	}
+	if len(c.exec) > 0 {
+		actions, err := marshalActions(c.exec)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, fmt.Sprintf("exec(%s)", strings.Join(actions, ",")))
+	}
+
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("ovs: ct action requires at least one argument")
+	}
+
+	return []byte(fmt.Sprintf("ct(%s)", strings.Join(clauses, ","))), nil
+}
+
+// parseCTAction parses the comma-separated body of a ct(...) action into a
+// CTAction. A clause that isn't recognized at all (rather than one that is
+// recognized but malformed) falls back to an opaque ConnectionTracking
+// action, so that future ct(...) forms aren't rejected outright.
+func parseCTAction(args string) (Action, error) {
+	clauses, err := splitArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid ct action: %v", err)
+	}
+
+	c := &CTAction{}
+	for _, cl := range clauses {
+		switch {
+		case cl == "commit":
+			c.commit = true
+		case cl == "force":
+			c.force = true
+		case strings.HasPrefix(cl, "table="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(cl, "table="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid ct table in %q: %v", cl, err)
+			}
+			t := uint8(v)
+			c.table = &t
+		case strings.HasPrefix(cl, "zone="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(cl, "zone="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid ct zone in %q: %v", cl, err)
+			}
+			z := uint16(v)
+			c.zone = &z
+		case strings.HasPrefix(cl, "alg="):
+			c.alg = strings.TrimPrefix(cl, "alg=")
+		case cl == "nat":
+			c.nat = NAT()
+		case strings.HasPrefix(cl, "nat("):
+			natArgs, err := parenArgs(cl, "nat")
+			if err != nil {
+				return nil, err
+			}
+			nat, err := parseNAT(natArgs)
+			if err != nil {
+				return nil, err
+			}
+			c.nat = nat
+		case strings.HasPrefix(cl, "exec("):
+			execArgs, err := parenArgs(cl, "exec")
+			if err != nil {
+				return nil, err
+			}
+			execRaw, err := splitArgs(execArgs)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range execRaw {
+				act, err := parseAction(e)
+				if err != nil {
+					return nil, fmt.Errorf("ovs: invalid ct exec clause: %v", err)
+				}
+				c.exec = append(c.exec, act)
+			}
+		default:
+			// Unrecognized clause, possibly a future ct(...) form; fall
+			// back to the opaque representation rather than rejecting
+			// the flow outright.
+			return ConnectionTracking(args), nil
+		}
+	}
+
+	return c, nil
+}
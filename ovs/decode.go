@@ -0,0 +1,269 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A DecodedAction is a single flow action decoded into a tree of typed
+// key/value pairs, so that callers can inspect the fields of compound
+// actions such as ct(...) and learn(...) without re-parsing their textual
+// form.
+//
+// Value holds one of: nil (for keyword-only actions such as "drop"), a
+// scalar (string, int or uint value), a map[string]any (for ct, bundle
+// and bundle_load), or a []DecodedAction (for learn and for a ct action's
+// nested exec(...) clause).
+type DecodedAction struct {
+	Key   string
+	Value interface{}
+}
+
+// DecodeActions parses s, a comma-separated list of flow actions, into a
+// slice of DecodedAction describing each action as a typed tree rather
+// than an opaque Action value.
+func DecodeActions(s string) ([]DecodedAction, error) {
+	raw, err := splitActions(s)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]DecodedAction, 0, len(raw))
+	for _, r := range raw {
+		d, err := decodeAction(r)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, d)
+	}
+
+	return out, nil
+}
+
+// decodeAction decodes a single raw action string into a DecodedAction.
+func decodeAction(s string) (DecodedAction, error) {
+	switch {
+	case strings.HasPrefix(s, "ct("):
+		args, err := parenArgs(s, "ct")
+		if err != nil {
+			return DecodedAction{}, err
+		}
+
+		v, err := decodeCT(args)
+		if err != nil {
+			return DecodedAction{}, err
+		}
+
+		return DecodedAction{Key: "ct", Value: v}, nil
+	case strings.HasPrefix(s, "learn("):
+		args, err := parenArgs(s, "learn")
+		if err != nil {
+			return DecodedAction{}, err
+		}
+
+		v, err := decodeLearn(args)
+		if err != nil {
+			return DecodedAction{}, err
+		}
+
+		return DecodedAction{Key: "learn", Value: v}, nil
+	case strings.HasPrefix(s, "bundle_load("), strings.HasPrefix(s, "bundle("):
+		a, err := parseAction(s)
+		if err != nil {
+			return DecodedAction{}, err
+		}
+
+		b := a.(*bundle)
+		v := map[string]interface{}{
+			"fields":     b.Fields,
+			"basis":      b.Basis,
+			"algorithm":  b.Algorithm,
+			"slave_type": b.SlaveType,
+			"members":    b.Members,
+		}
+		if b.Dst != "" {
+			v["dst"] = b.Dst
+		}
+
+		return DecodedAction{Key: b.name(), Value: v}, nil
+	case strings.HasPrefix(s, "output:"):
+		v := strings.TrimPrefix(s, "output:")
+		if strings.ContainsAny(v, "[]") {
+			return DecodedAction{Key: "output", Value: v}, nil
+		}
+
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return DecodedAction{}, fmt.Errorf("ovs: invalid output port in %q: %v", s, err)
+		}
+
+		return DecodedAction{Key: "output", Value: port}, nil
+	case strings.HasPrefix(s, "group:"):
+		id, err := strconv.ParseUint(strings.TrimPrefix(s, "group:"), 10, 32)
+		if err != nil {
+			return DecodedAction{}, fmt.Errorf("ovs: invalid group id in %q: %v", s, err)
+		}
+
+		return DecodedAction{Key: "group", Value: uint32(id)}, nil
+	case strings.HasPrefix(s, "push_vlan:"), strings.HasPrefix(s, "push_mpls:"), strings.HasPrefix(s, "pop_mpls:"):
+		name := s[:strings.IndexByte(s, ':')]
+		v, err := parseHexUint(s[len(name)+1:], 16)
+		if err != nil {
+			return DecodedAction{}, fmt.Errorf("ovs: invalid %s value in %q: %v", name, s, err)
+		}
+
+		return DecodedAction{Key: name, Value: uint16(v)}, nil
+	case !strings.ContainsAny(s, ":("):
+		// A bare keyword action, such as "drop" or "pop_vlan".
+		return DecodedAction{Key: s}, nil
+	default:
+		// Fall back to the action's own name and raw remainder, so that
+		// any action recognized by parseAction is still representable
+		// even without a dedicated decoder.
+		if idx := strings.IndexAny(s, ":("); idx >= 0 {
+			return DecodedAction{Key: s[:idx], Value: s[idx+1:]}, nil
+		}
+
+		return DecodedAction{Key: s}, nil
+	}
+}
+
+// parenArgs validates that s has the form "name(...)" and returns the
+// contents between the outermost parentheses.
+func parenArgs(s, name string) (string, error) {
+	prefix := name + "("
+	if !strings.HasPrefix(s, prefix) || !strings.HasSuffix(s, ")") {
+		return "", fmt.Errorf("ovs: invalid %s action: %q", name, s)
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(s, prefix), ")"), nil
+}
+
+// decodeCT decodes the comma-separated body of a ct(...) action into its
+// constituent clauses.
+func decodeCT(args string) (map[string]interface{}, error) {
+	clauses, err := splitArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid ct action: %v", err)
+	}
+
+	m := make(map[string]interface{})
+	for _, c := range clauses {
+		switch {
+		case c == "commit":
+			m["commit"] = true
+		case c == "force":
+			m["force"] = true
+		case strings.HasPrefix(c, "table="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "table="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid ct table in %q: %v", c, err)
+			}
+			m["table"] = uint8(v)
+		case strings.HasPrefix(c, "zone="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "zone="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid ct zone in %q: %v", c, err)
+			}
+			m["zone"] = uint16(v)
+		case strings.HasPrefix(c, "alg="):
+			m["alg"] = strings.TrimPrefix(c, "alg=")
+		case strings.HasPrefix(c, "nat("):
+			natArgs, err := parenArgs(c, "nat")
+			if err != nil {
+				return nil, err
+			}
+			m["nat"] = natArgs
+		case strings.HasPrefix(c, "exec("):
+			execArgs, err := parenArgs(c, "exec")
+			if err != nil {
+				return nil, err
+			}
+
+			exec, err := DecodeActions(execArgs)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid ct exec clause: %v", err)
+			}
+			m["exec"] = exec
+		default:
+			return nil, fmt.Errorf("ovs: unrecognized ct clause: %q", c)
+		}
+	}
+
+	return m, nil
+}
+
+// decodeLearn decodes the comma-separated body of a learn(...) action into
+// a tree of DecodedAction, preserving the original clause order.
+func decodeLearn(args string) ([]DecodedAction, error) {
+	clauses, err := splitArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid learn action: %v", err)
+	}
+
+	out := make([]DecodedAction, 0, len(clauses))
+	for _, c := range clauses {
+		switch {
+		case strings.HasPrefix(c, "table="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "table="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn table in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "table", Value: uint8(v)})
+		case strings.HasPrefix(c, "priority="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "priority="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn priority in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "priority", Value: uint16(v)})
+		case strings.HasPrefix(c, "in_port="):
+			v, err := strconv.Atoi(strings.TrimPrefix(c, "in_port="))
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn in_port in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "in_port", Value: v})
+		case strings.HasPrefix(c, "dl_type="):
+			v, err := parseHexUint(strings.TrimPrefix(c, "dl_type="), 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn dl_type in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "dl_type", Value: uint16(v)})
+		case strings.HasPrefix(c, "nw_proto="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "nw_proto="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn nw_proto in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "nw_proto", Value: uint8(v)})
+		case strings.HasPrefix(c, "tp_src="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "tp_src="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn tp_src in %q: %v", c, err)
+			}
+			out = append(out, DecodedAction{Key: "tp_src", Value: uint16(v)})
+		default:
+			d, err := decodeAction(c)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn clause %q: %v", c, err)
+			}
+			out = append(out, d)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"testing"
+)
+
+func Test_DecodeActions_ct(t *testing.T) {
+	in := "ct(commit,exec(set_field:1->ct_label,set_field:1->ct_mark))"
+
+	got, err := DecodeActions(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := 1, len(got); want != got {
+		t.Fatalf("unexpected number of decoded actions: want %d, got %d", want, got)
+	}
+
+	ct := got[0]
+	if want, got := "ct", ct.Key; want != got {
+		t.Fatalf("unexpected key: want %q, got %q", want, got)
+	}
+
+	m, ok := ct.Value.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected ct value to be a map[string]interface{}, got %T", ct.Value)
+	}
+
+	if commit, ok := m["commit"].(bool); !ok || !commit {
+		t.Fatalf("expected ct commit clause to be true, got %v", m["commit"])
+	}
+
+	exec, ok := m["exec"].([]DecodedAction)
+	if !ok {
+		t.Fatalf("expected ct exec clause to be a []DecodedAction, got %T", m["exec"])
+	}
+
+	if want, got := 2, len(exec); want != got {
+		t.Fatalf("unexpected number of exec actions: want %d, got %d", want, got)
+	}
+
+	if want, got := "set_field", exec[0].Key; want != got {
+		t.Fatalf("unexpected exec action key: want %q, got %q", want, got)
+	}
+}
+
+func Test_DecodeActions_learn(t *testing.T) {
+	in := "learn(table=10,priority=10000,in_port=1,dl_type=0x0800,nw_proto=6,tp_src=80,load:NXM_OF_ETH_DST[]->NXM_OF_ETH_SRC[],load:NXM_OF_ETH_SRC[]->NXM_OF_ETH_DST[],load:NXM_OF_IP_DST[]->NXM_OF_IP_SRC[],load:NXM_OF_TCP_DST[]->NXM_OF_TCP_SRC[],output:NXM_OF_IN_PORT[]),mod_dl_dst:00:24:fd:4f:0a:26,mod_nw_dst:172.16.222.254,mod_tp_dst:80,output:1"
+
+	got, err := DecodeActions(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want, got := 5, len(got); want != got {
+		t.Fatalf("unexpected number of decoded actions: want %d, got %d", want, got)
+	}
+
+	learn := got[0]
+	if want, got := "learn", learn.Key; want != got {
+		t.Fatalf("unexpected key: want %q, got %q", want, got)
+	}
+
+	clauses, ok := learn.Value.([]DecodedAction)
+	if !ok {
+		t.Fatalf("expected learn value to be a []DecodedAction, got %T", learn.Value)
+	}
+
+	if want, got := uint8(10), clauses[0].Value; want != got {
+		t.Fatalf("unexpected table value: want %v, got %v", want, got)
+	}
+
+	if want, got := "table", clauses[0].Key; want != got {
+		t.Fatalf("unexpected first clause key: want %q, got %q", want, got)
+	}
+
+	last := clauses[len(clauses)-1]
+	if want, got := "output", last.Key; want != got {
+		t.Fatalf("unexpected last clause key: want %q, got %q", want, got)
+	}
+
+	if want, got := "NXM_OF_IN_PORT[]", last.Value; want != got {
+		t.Fatalf("unexpected output value: want %q, got %q", want, got)
+	}
+
+	if want, got := "output", got[4].Key; want != got {
+		t.Fatalf("unexpected trailing action key: want %q, got %q", want, got)
+	}
+
+	if want, got := 1, got[4].Value; want != got {
+		t.Fatalf("unexpected trailing output port: want %d, got %v", want, got)
+	}
+}
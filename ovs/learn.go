@@ -0,0 +1,140 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A LearnedFlow describes the flow that should be created by a Learn
+// action: the table it should be installed into, its priority, the
+// inbound port it is tied to, and the matches and actions it should carry.
+type LearnedFlow struct {
+	Table    uint8
+	Priority uint16
+	InPort   int
+	Matches  []Match
+	Actions  []Action
+}
+
+// A learn is an Action which installs a new flow into another flow table,
+// based on the contents of a matched packet.
+type learn struct {
+	Flow *LearnedFlow
+}
+
+// Learn creates an Action that installs the flow described by f into
+// another flow table.
+func Learn(f *LearnedFlow) Action {
+	return &learn{Flow: f}
+}
+
+func (a *learn) GoString() string {
+	return fmt.Sprintf("ovs.Learn(%#v)", a.Flow)
+}
+
+func (a *learn) MarshalText() ([]byte, error) {
+	f := a.Flow
+
+	clauses := []string{
+		fmt.Sprintf("table=%d", f.Table),
+		fmt.Sprintf("priority=%d", f.Priority),
+		fmt.Sprintf("in_port=%d", f.InPort),
+	}
+
+	for _, m := range f.Matches {
+		text, err := m.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		clauses = append(clauses, string(text))
+	}
+
+	for _, act := range f.Actions {
+		text, err := act.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+
+		clauses = append(clauses, string(text))
+	}
+
+	return []byte(fmt.Sprintf("learn(%s)", strings.Join(clauses, ","))), nil
+}
+
+func parseLearn(s string) (Action, error) {
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("ovs: invalid learn action: %q", s)
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(s, "learn("), ")")
+	clauses, err := splitArgs(inner)
+	if err != nil {
+		return nil, fmt.Errorf("ovs: invalid learn action: %v", err)
+	}
+
+	f := &LearnedFlow{}
+	for _, c := range clauses {
+		switch {
+		case strings.HasPrefix(c, "table="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "table="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn table in %q: %v", s, err)
+			}
+			f.Table = uint8(v)
+		case strings.HasPrefix(c, "priority="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "priority="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn priority in %q: %v", s, err)
+			}
+			f.Priority = uint16(v)
+		case strings.HasPrefix(c, "in_port="):
+			v, err := strconv.Atoi(strings.TrimPrefix(c, "in_port="))
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn in_port in %q: %v", s, err)
+			}
+			f.InPort = v
+		case strings.HasPrefix(c, "dl_type="):
+			v, err := parseHexUint(strings.TrimPrefix(c, "dl_type="), 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn dl_type in %q: %v", s, err)
+			}
+			f.Matches = append(f.Matches, DataLinkType(uint16(v)))
+		case strings.HasPrefix(c, "nw_proto="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "nw_proto="), 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn nw_proto in %q: %v", s, err)
+			}
+			f.Matches = append(f.Matches, NetworkProtocol(uint8(v)))
+		case strings.HasPrefix(c, "tp_src="):
+			v, err := strconv.ParseUint(strings.TrimPrefix(c, "tp_src="), 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn tp_src in %q: %v", s, err)
+			}
+			f.Matches = append(f.Matches, TransportSourcePort(uint16(v)))
+		default:
+			act, err := parseAction(c)
+			if err != nil {
+				return nil, fmt.Errorf("ovs: invalid learn clause %q: %v", c, err)
+			}
+			f.Actions = append(f.Actions, act)
+		}
+	}
+
+	return Learn(f), nil
+}
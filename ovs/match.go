@@ -0,0 +1,79 @@
+// Copyright 2017 DigitalOcean.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovs
+
+import "fmt"
+
+// A Match is a flow match, used within a LearnedFlow to describe the
+// fields a learned flow should match against.
+type Match interface {
+	GoString() string
+	MarshalText() (text []byte, err error)
+}
+
+// A dataLinkType is a Match against a packet's Ethernet type.
+type dataLinkType struct {
+	EtherType uint16
+}
+
+// DataLinkType creates a Match against a packet's Ethernet type.
+func DataLinkType(etherType uint16) Match {
+	return &dataLinkType{EtherType: etherType}
+}
+
+func (m *dataLinkType) GoString() string {
+	return fmt.Sprintf("ovs.DataLinkType(%#04x)", m.EtherType)
+}
+
+func (m *dataLinkType) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("dl_type=0x%04x", m.EtherType)), nil
+}
+
+// A networkProtocol is a Match against a packet's IP protocol number.
+type networkProtocol struct {
+	Protocol uint8
+}
+
+// NetworkProtocol creates a Match against a packet's IP protocol number.
+func NetworkProtocol(protocol uint8) Match {
+	return &networkProtocol{Protocol: protocol}
+}
+
+func (m *networkProtocol) GoString() string {
+	return fmt.Sprintf("ovs.NetworkProtocol(%d)", m.Protocol)
+}
+
+func (m *networkProtocol) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("nw_proto=%d", m.Protocol)), nil
+}
+
+// A transportSourcePort is a Match against a packet's TCP/UDP source port.
+type transportSourcePort struct {
+	Port uint16
+}
+
+// TransportSourcePort creates a Match against a packet's TCP/UDP source
+// port.
+func TransportSourcePort(port uint16) Match {
+	return &transportSourcePort{Port: port}
+}
+
+func (m *transportSourcePort) GoString() string {
+	return fmt.Sprintf("ovs.TransportSourcePort(%d)", m.Port)
+}
+
+func (m *transportSourcePort) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("tp_src=%d", m.Port)), nil
+}